@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// tokenProvider obtains a DSV access token, independent of which grant type
+// is used to get it. Selecting a provider is the only thing AUTH_METHOD
+// controls; everything downstream of GetToken is unaware of it.
+type tokenProvider interface {
+	GetToken(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint string) (string, error)
+}
+
+// ClientCredentialsProvider authenticates with a static CLIENT_ID/CLIENT_SECRET
+// pair via the client_credentials grant. This is the default, used when
+// AUTH_METHOD is unset.
+type ClientCredentialsProvider struct {
+	ClientID     string
+	ClientSecret string
+}
+
+func (p *ClientCredentialsProvider) GetToken(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint string) (string, error) {
+	return dsvGetToken(ctx, c, cfg, apiEndpoint, p.ClientID, p.ClientSecret)
+}
+
+// OIDCProvider authenticates by exchanging the CI runner's own OIDC ID token
+// for a DSV access token, via AUTH_METHOD=oidc. This avoids storing a
+// long-lived CLIENT_SECRET in CI config at all.
+type OIDCProvider struct {
+	Runner CIRunner
+}
+
+func (p *OIDCProvider) GetToken(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint string) (string, error) {
+	idToken, err := p.Runner.FetchOIDCIDToken(ctx, c)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch OIDC ID token: %v", err)
+	}
+	return dsvGetTokenOIDC(ctx, c, cfg, apiEndpoint, idToken)
+}
+
+// dsvGetTokenOIDC exchanges idToken for a DSV access token via the
+// jwt-bearer grant.
+func dsvGetTokenOIDC(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint, idToken string) (string, error) {
+	endpoint := apiEndpoint + "/token"
+	reqBody := []byte(fmt.Sprintf(
+		`{"grant_type":"urn:ietf:params:oauth:grant-type:jwt-bearer","assertion":"%s"}`,
+		idToken,
+	))
+
+	resp, err := doWithRetry(ctx, c, cfg, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("could not build request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Delinea-DSV-Client", "gh-action")
+		return req, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("API call failed: %v", err)
+	}
+	return parseTokenResponse(resp, endpoint, "check the OIDC trust configured in DSV")
+}
+
+// parseTokenResponse extracts accessToken from a DSV /token response,
+// shared by the client_credentials and OIDC grants. authHint is appended to
+// the error message on an auth failure, since the two grants point at
+// different misconfigurations.
+func parseTokenResponse(resp *http.Response, endpoint, authHint string) (string, error) {
+	if isAuthFailure(resp.StatusCode) {
+		return "", fmt.Errorf("POST %s: %s (%s)", endpoint, resp.Status, authHint)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("POST %s: %s", endpoint, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read response body: %v", err)
+	}
+	tokenRespData := make(map[string]interface{})
+	if err := json.Unmarshal(body, &tokenRespData); err != nil {
+		return "", fmt.Errorf("could not unmarshal response body: %v", err)
+	}
+
+	token, strExists := tokenRespData["accessToken"].(string)
+	if !strExists {
+		return "", fmt.Errorf("could not read access token from response")
+	}
+	return token, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDsvGetTokenOIDC(t *testing.T) {
+	cases := []struct {
+		name        string
+		apiEndpoint string
+		idToken     string
+		client      httpClient
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "happy path",
+			apiEndpoint: "test.example.com",
+			idToken:     "id-token",
+			client: &MockHttpClient{
+				response: &http.Response{
+					Status:     "200 OK",
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"accessToken": "token"}`))),
+				},
+			},
+			want: "token",
+		},
+		{
+			name:        "auth failure",
+			apiEndpoint: "test.example.com",
+			idToken:     "id-token",
+			client: &MockHttpClient{
+				response: &http.Response{Status: "403 Forbidden", StatusCode: 403, Body: io.NopCloser(bytes.NewReader(nil))},
+			},
+			wantErr: fmt.Errorf("POST test.example.com/token: 403 Forbidden (check the OIDC trust configured in DSV)"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := dsvGetTokenOIDC(context.Background(), tc.client, noRetryConfig, tc.apiEndpoint, tc.idToken)
+			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+			if tc.want != result {
+				t.Errorf("want %v, got %v", tc.want, result)
+			}
+		})
+	}
+}
+
+// stubOIDCRunner embeds CIRunner so it only needs to implement
+// FetchOIDCIDToken; every other call would panic on the nil interface, but
+// OIDCProvider.GetToken never reaches them.
+type stubOIDCRunner struct {
+	CIRunner
+	idToken string
+	err     error
+}
+
+func (r *stubOIDCRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	return r.idToken, r.err
+}
+
+func TestOIDCProviderGetToken(t *testing.T) {
+	t.Run("id token fetch fails", func(t *testing.T) {
+		provider := &OIDCProvider{Runner: &stubOIDCRunner{err: fmt.Errorf("no permission")}}
+		_, err := provider.GetToken(context.Background(), &MockHttpClient{}, noRetryConfig, "test.example.com")
+		wantErr := "could not fetch OIDC ID token: no permission"
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("want error %q, got %v", wantErr, err)
+		}
+	})
+
+	t.Run("happy path", func(t *testing.T) {
+		client := &MockHttpClient{
+			response: &http.Response{
+				Status:     "200 OK",
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"accessToken": "token"}`))),
+			},
+		}
+		provider := &OIDCProvider{Runner: &stubOIDCRunner{idToken: "id-token"}}
+		got, err := provider.GetToken(context.Background(), client, noRetryConfig, "test.example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "token" {
+			t.Errorf("want %q, got %q", "token", got)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// bitbucketRunner implements CIRunner for Bitbucket Pipelines. Bitbucket has
+// no built-in mechanism for sharing env vars across steps or for masking
+// values beyond its own "secured" variables UI, so output is limited to
+// plain logging.
+type bitbucketRunner struct {
+	masker secretMasker
+}
+
+func (r *bitbucketRunner) Name() string { return "Bitbucket Pipelines" }
+
+func (r *bitbucketRunner) Detect() bool {
+	return os.Getenv("BITBUCKET_BUILD_NUMBER") != ""
+}
+
+// ShouldSetEnv always returns false: Bitbucket Pipelines has no built-in
+// mechanism for sharing env vars across steps, so honoring SET_ENV here
+// would silently export nothing.
+func (r *bitbucketRunner) ShouldSetEnv() bool {
+	return false
+}
+
+func (r *bitbucketRunner) OpenEnvFile() (*os.File, error) {
+	return nil, nil
+}
+
+func (r *bitbucketRunner) SetOutput(key, val string) {}
+
+func (r *bitbucketRunner) ExportVar(envFile *os.File, key, val string) error {
+	return nil
+}
+
+func (r *bitbucketRunner) Info(s string) {
+	fmt.Fprintln(r.masker.Wrap(os.Stdout), s)
+}
+
+func (r *bitbucketRunner) Debug(s string) {
+	if os.Getenv("DEBUG") != "" {
+		fmt.Fprintf(r.masker.Wrap(os.Stdout), "[debug] %s\n", s)
+	}
+}
+
+func (r *bitbucketRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %v\n", err)
+}
+
+func (r *bitbucketRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %s\n", s)
+}
+
+func (r *bitbucketRunner) Notice(s string) {
+	r.Info(s)
+}
+
+func (r *bitbucketRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+}
+
+func (r *bitbucketRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	return "", fmt.Errorf("OIDC authentication is not supported on %s", r.Name())
+}
+
+// WriteStepSummary is a no-op: Bitbucket Pipelines has no job-summary
+// mechanism.
+func (r *bitbucketRunner) WriteStepSummary(summary runSummary) error {
+	return nil
+}
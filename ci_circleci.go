@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// circleciRunner implements CIRunner for CircleCI. CircleCI shares env vars
+// across steps of a job via the file named by $BASH_ENV, which is sourced
+// before every step's shell runs.
+type circleciRunner struct {
+	masker secretMasker
+}
+
+func (r *circleciRunner) Name() string { return "CircleCI" }
+
+func (r *circleciRunner) Detect() bool {
+	return os.Getenv("CIRCLECI") != ""
+}
+
+func (r *circleciRunner) ShouldSetEnv() bool {
+	return os.Getenv("BASH_ENV") != ""
+}
+
+func (r *circleciRunner) OpenEnvFile() (*os.File, error) {
+	envFileName := os.Getenv("BASH_ENV")
+	if envFileName == "" {
+		return nil, fmt.Errorf("BASH_ENV environment is not defined")
+	}
+	envFile, err := os.OpenFile(envFileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %s: %v", envFileName, err)
+	}
+	return envFile, nil
+}
+
+func (r *circleciRunner) SetOutput(key, val string) {}
+
+func (r *circleciRunner) ExportVar(envFile *os.File, key, val string) error {
+	if envFile == nil {
+		return nil
+	}
+	if _, err := envFile.WriteString(fmt.Sprintf("export %s=%s\n", strings.ToUpper(key), val)); err != nil {
+		return fmt.Errorf("could not update %s environment file: %v", envFile.Name(), err)
+	}
+	return nil
+}
+
+func (r *circleciRunner) Info(s string) {
+	fmt.Fprintln(r.masker.Wrap(os.Stdout), s)
+}
+
+func (r *circleciRunner) Debug(s string) {
+	if os.Getenv("CIRCLECI_DEBUG") != "" {
+		fmt.Fprintf(r.masker.Wrap(os.Stdout), "[debug] %s\n", s)
+	}
+}
+
+func (r *circleciRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %v\n", err)
+}
+
+func (r *circleciRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %s\n", s)
+}
+
+func (r *circleciRunner) Notice(s string) {
+	r.Info(s)
+}
+
+func (r *circleciRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+}
+
+func (r *circleciRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	return "", fmt.Errorf("OIDC authentication is not supported on %s", r.Name())
+}
+
+// WriteStepSummary is a no-op: CircleCI has no job-summary mechanism.
+func (r *circleciRunner) WriteStepSummary(summary runSummary) error {
+	return nil
+}
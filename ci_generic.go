@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// genericRunner is the fallback CIRunner used when no known CI is detected.
+// It logs plainly to stdout and supports neither step outputs nor env-file
+// export, since there is no well-known mechanism to target.
+type genericRunner struct {
+	masker secretMasker
+}
+
+func (r *genericRunner) Name() string { return "generic CI" }
+
+func (r *genericRunner) Detect() bool { return true }
+
+// ShouldSetEnv always returns false: generic CI has no known env-file
+// mechanism, so honoring SET_ENV here would silently export nothing.
+func (r *genericRunner) ShouldSetEnv() bool {
+	return false
+}
+
+func (r *genericRunner) OpenEnvFile() (*os.File, error) {
+	return nil, nil
+}
+
+func (r *genericRunner) SetOutput(key, val string) {}
+
+func (r *genericRunner) ExportVar(envFile *os.File, key, val string) error {
+	return nil
+}
+
+func (r *genericRunner) Info(s string) {
+	fmt.Fprintln(r.masker.Wrap(os.Stdout), s)
+}
+
+func (r *genericRunner) Debug(s string) {
+	if os.Getenv("DEBUG") != "" {
+		fmt.Fprintf(r.masker.Wrap(os.Stdout), "[debug] %s\n", s)
+	}
+}
+
+func (r *genericRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %v\n", err)
+}
+
+func (r *genericRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %s\n", s)
+}
+
+func (r *genericRunner) Notice(s string) {
+	r.Info(s)
+}
+
+func (r *genericRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+}
+
+func (r *genericRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	return "", fmt.Errorf("OIDC authentication is not supported on %s", r.Name())
+}
+
+// WriteStepSummary is a no-op: generic CI has no known job-summary
+// mechanism.
+func (r *genericRunner) WriteStepSummary(summary runSummary) error {
+	return nil
+}
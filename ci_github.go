@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// githubRunner implements CIRunner for GitHub Actions.
+type githubRunner struct {
+	masker secretMasker
+}
+
+func (r *githubRunner) Name() string { return "GitHub Actions" }
+
+func (r *githubRunner) Detect() bool {
+	return os.Getenv("GITHUB_ACTION") != ""
+}
+
+func (r *githubRunner) ShouldSetEnv() bool {
+	return os.Getenv("SET_ENV") != ""
+}
+
+func (r *githubRunner) OpenEnvFile() (*os.File, error) {
+	envFileName := os.Getenv("GITHUB_ENV")
+	if envFileName == "" {
+		return nil, fmt.Errorf("GITHUB_ENV environment is not defined")
+	}
+	envFile, err := os.OpenFile(envFileName, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %s: %v", envFileName, err)
+	}
+	return envFile, nil
+}
+
+func (r *githubRunner) SetOutput(key, val string) {
+	fmt.Printf("::set-output name=%s::%s\n", key, val)
+}
+
+func (r *githubRunner) ExportVar(envFile *os.File, key, val string) error {
+	if envFile == nil {
+		return nil
+	}
+	if _, err := envFile.WriteString(fmt.Sprintf("%s=%s\n", strings.ToUpper(key), val)); err != nil {
+		return fmt.Errorf("could not update %s environment file: %v", envFile.Name(), err)
+	}
+	return nil
+}
+
+func (r *githubRunner) Info(s string) {
+	fmt.Fprintln(r.masker.Wrap(os.Stdout), s)
+}
+
+func (r *githubRunner) Debug(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "::debug::%s\n", s)
+}
+
+func (r *githubRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "::error title=DSV CI Plugin::%v\n", err)
+}
+
+func (r *githubRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "::error title=DSV CI Plugin::%s\n", s)
+}
+
+func (r *githubRunner) Notice(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "::notice::%s\n", s)
+}
+
+// MaskSecret registers val with the GitHub Actions runner's own log masking
+// via the add-mask workflow command, in addition to scrubbing it from any
+// output this plugin writes directly.
+func (r *githubRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+	fmt.Printf("::add-mask::%s\n", val)
+}
+
+// FetchOIDCIDToken requests a job-scoped OIDC ID token from GitHub's Actions
+// runtime. It requires the job to have been granted the "id-token: write"
+// permission, which is what populates ACTIONS_ID_TOKEN_REQUEST_URL/TOKEN.
+func (r *githubRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	reqURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	reqToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if reqURL == "" || reqToken == "" {
+		return "", fmt.Errorf("ACTIONS_ID_TOKEN_REQUEST_URL/ACTIONS_ID_TOKEN_REQUEST_TOKEN are not defined; grant this job 'id-token: write' permission")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+reqToken)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("API call failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", reqURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read response body: %v", err)
+	}
+	tokenResp := struct {
+		Value string `json:"value"`
+	}{}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("could not unmarshal response body: %v", err)
+	}
+	if tokenResp.Value == "" {
+		return "", fmt.Errorf("could not read ID token from response")
+	}
+	return tokenResp.Value, nil
+}
+
+// WriteStepSummary appends a markdown table of what was fetched to
+// GITHUB_STEP_SUMMARY, the job summary file GitHub Actions renders in the
+// run's UI. It is a no-op when SUMMARY=false or GITHUB_STEP_SUMMARY isn't
+// set (runners predating this feature). An unwritable summary file is
+// returned as an error for the caller to log; since the summary is purely
+// cosmetic, callers should not treat that error as fatal to the run.
+func (r *githubRunner) WriteStepSummary(summary runSummary) error {
+	if os.Getenv("SUMMARY") == "false" {
+		return nil
+	}
+	summaryFileName := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFileName == "" {
+		return nil
+	}
+	summaryFile, err := os.OpenFile(summaryFileName, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open file %s: %v", summaryFileName, err)
+	}
+	defer summaryFile.Close()
+	if _, err := summaryFile.WriteString(summary.markdownTable()); err != nil {
+		return fmt.Errorf("could not update %s step summary file: %v", summaryFileName, err)
+	}
+	return nil
+}
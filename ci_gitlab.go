@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// gitlabRunner implements CIRunner for GitLab CI.
+type gitlabRunner struct {
+	masker secretMasker
+}
+
+func (r *gitlabRunner) Name() string { return "GitLab CI" }
+
+func (r *gitlabRunner) Detect() bool {
+	return os.Getenv("GITLAB_CI") != ""
+}
+
+func (r *gitlabRunner) ShouldSetEnv() bool {
+	return true
+}
+
+func (r *gitlabRunner) OpenEnvFile() (*os.File, error) {
+	jobName := os.Getenv("CI_JOB_NAME")
+	if jobName == "" {
+		return nil, fmt.Errorf("CI_JOB_NAME environment is not defined")
+	}
+	pwd := os.Getenv("CI_PROJECT_PATH")
+	if pwd == "" {
+		return nil, fmt.Errorf("CI_PROJECT_PATH environment is not defined")
+	}
+	envFileName := path.Join("/builds/", pwd, jobName)
+	envFile, err := os.OpenFile(envFileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %s: %v", envFileName, err)
+	}
+	return envFile, nil
+}
+
+func (r *gitlabRunner) SetOutput(key, val string) {}
+
+func (r *gitlabRunner) ExportVar(envFile *os.File, key, val string) error {
+	if envFile == nil {
+		return nil
+	}
+	if _, err := envFile.WriteString(fmt.Sprintf("%s=%s\n", strings.ToUpper(key), val)); err != nil {
+		return fmt.Errorf("could not update %s environment file: %v", envFile.Name(), err)
+	}
+	return nil
+}
+
+func (r *gitlabRunner) Info(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "\x1b[92m%s\x1b[0m\n", s)
+}
+
+func (r *gitlabRunner) Debug(s string) {
+	if os.Getenv("GITLAB_CI_DEBUG") != "" {
+		fmt.Fprintf(r.masker.Wrap(os.Stdout), "##[debug]\x1b[94m%s\x1b[0m\n", s)
+	}
+}
+
+func (r *gitlabRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "\x1b[91m%v\x1b[0m\n", err)
+}
+
+func (r *gitlabRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "\x1b[91m%s\x1b[0m\n", s)
+}
+
+func (r *gitlabRunner) Notice(s string) {
+	r.Info(s)
+}
+
+// MaskSecret registers val so it is scrubbed from any output this plugin
+// writes directly. GitLab's own masking only applies to variables flagged
+// "masked" ahead of time in project settings, which doesn't cover values
+// fetched from DSV at runtime.
+func (r *gitlabRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+}
+
+// FetchOIDCIDToken returns the job's ID token, as made available by an
+// `id_tokens:` block configured on this job (e.g. `id_tokens: {DSV_ID_TOKEN:
+// {aud: https://dsv}}`). GitLab hands the job the token directly rather than
+// requiring a callback, so there is no HTTP call to make here.
+func (r *gitlabRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	idToken := os.Getenv("CI_JOB_JWT_V2")
+	if idToken == "" {
+		return "", fmt.Errorf("CI_JOB_JWT_V2 environment is not defined; configure an 'id_tokens:' block for this job")
+	}
+	return idToken, nil
+}
+
+// WriteStepSummary is a no-op: GitLab CI has no job-summary mechanism.
+func (r *gitlabRunner) WriteStepSummary(summary runSummary) error {
+	return nil
+}
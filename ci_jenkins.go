@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// jenkinsRunner implements CIRunner for Jenkins. Jenkins sets JENKINS_URL on
+// every build; there is no standard cross-step env file for freestyle or
+// scripted pipelines, so output is limited to plain logging.
+type jenkinsRunner struct {
+	masker secretMasker
+}
+
+func (r *jenkinsRunner) Name() string { return "Jenkins" }
+
+func (r *jenkinsRunner) Detect() bool {
+	return os.Getenv("JENKINS_URL") != ""
+}
+
+// ShouldSetEnv always returns false: there is no standard cross-step env
+// file on Jenkins, so honoring SET_ENV here would silently export nothing.
+func (r *jenkinsRunner) ShouldSetEnv() bool {
+	return false
+}
+
+func (r *jenkinsRunner) OpenEnvFile() (*os.File, error) {
+	return nil, nil
+}
+
+func (r *jenkinsRunner) SetOutput(key, val string) {}
+
+func (r *jenkinsRunner) ExportVar(envFile *os.File, key, val string) error {
+	return nil
+}
+
+func (r *jenkinsRunner) Info(s string) {
+	fmt.Fprintln(r.masker.Wrap(os.Stdout), s)
+}
+
+func (r *jenkinsRunner) Debug(s string) {
+	if os.Getenv("DEBUG") != "" {
+		fmt.Fprintf(r.masker.Wrap(os.Stdout), "[debug] %s\n", s)
+	}
+}
+
+func (r *jenkinsRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %v\n", err)
+}
+
+func (r *jenkinsRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %s\n", s)
+}
+
+func (r *jenkinsRunner) Notice(s string) {
+	r.Info(s)
+}
+
+func (r *jenkinsRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+}
+
+func (r *jenkinsRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	return "", fmt.Errorf("OIDC authentication is not supported on %s", r.Name())
+}
+
+// WriteStepSummary is a no-op: Jenkins has no job-summary mechanism.
+func (r *jenkinsRunner) WriteStepSummary(summary runSummary) error {
+	return nil
+}
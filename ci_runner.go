@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+)
+
+// CIRunner abstracts the CI-specific behavior needed to fetch secrets from
+// DSV and surface them to a pipeline: how to detect the current CI, how
+// logs are written, how outputs/env vars are exposed to later steps, and
+// how secret values are masked from log output. Adding support for a new CI
+// means adding a new implementation and registering it in ciRunners, without
+// touching run().
+type CIRunner interface {
+	// Name returns a short, human readable name used in startup messages.
+	Name() string
+	// Detect reports whether the process is currently running under this CI.
+	Detect() bool
+	// ShouldSetEnv reports whether exported values should be written via
+	// OpenEnvFile/ExportVar for this CI and its current configuration.
+	ShouldSetEnv() bool
+	// OpenEnvFile opens the file that exported env vars should be appended
+	// to. It returns a nil file (and nil error) when this CI has no such
+	// mechanism, in which case ExportVar becomes a no-op.
+	OpenEnvFile() (*os.File, error)
+	// SetOutput exposes key/val as a step output, if this CI supports one.
+	SetOutput(key, val string)
+	// ExportVar appends a KEY=val line to envFile. Implementations must
+	// tolerate a nil envFile by doing nothing.
+	ExportVar(envFile *os.File, key, val string) error
+	Info(s string)
+	Debug(s string)
+	Error(err error)
+	StringError(s string)
+	// Notice announces a successful run. Where the CI has no distinct
+	// annotation for it, this behaves the same as Info.
+	Notice(s string)
+	// MaskSecret registers val so that it is scrubbed from any subsequent
+	// Info/Debug/Error/StringError output, and, where the CI supports it,
+	// from the raw job log as well.
+	MaskSecret(val string)
+	// FetchOIDCIDToken returns this CI's own OIDC ID token, for use with
+	// AUTH_METHOD=oidc. It returns an error if this CI doesn't expose one.
+	FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error)
+	// WriteStepSummary reports what a completed run fetched, if this CI has
+	// a job-summary mechanism to write it to. It is a no-op otherwise.
+	WriteStepSummary(summary runSummary) error
+}
+
+// ciRunners lists the supported CI runners in detection order. The first
+// runner whose Detect() returns true is used. genericRunner always matches
+// and must stay last so it only ever serves as a fallback.
+var ciRunners = []CIRunner{
+	&githubRunner{},
+	&gitlabRunner{},
+	&bitbucketRunner{},
+	&circleciRunner{},
+	&woodpeckerRunner{},
+	&jenkinsRunner{},
+	&genericRunner{},
+}
+
+// detectCIRunner returns the first CIRunner in ciRunners whose Detect()
+// matches the current environment, falling back to genericRunner.
+func detectCIRunner() CIRunner {
+	for _, r := range ciRunners {
+		if r.Detect() {
+			return r
+		}
+	}
+	return &genericRunner{}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearCIEnv unsets every env var used by Detect() across all runners so
+// tests can set only the ones they care about.
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	vars := []string{
+		"GITHUB_ACTION", "GITLAB_CI", "BITBUCKET_BUILD_NUMBER",
+		"CIRCLECI", "CI", "JENKINS_URL",
+	}
+	for _, v := range vars {
+		old, ok := os.LookupEnv(v)
+		os.Unsetenv(v)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(v, old)
+			}
+		})
+	}
+}
+
+func TestDetectCIRunner(t *testing.T) {
+	cases := []struct {
+		name string
+		envs map[string]string
+		want string
+	}{
+		{name: "github", envs: map[string]string{"GITHUB_ACTION": "1"}, want: "GitHub Actions"},
+		{name: "gitlab", envs: map[string]string{"GITLAB_CI": "true"}, want: "GitLab CI"},
+		{name: "bitbucket", envs: map[string]string{"BITBUCKET_BUILD_NUMBER": "1"}, want: "Bitbucket Pipelines"},
+		{name: "circleci", envs: map[string]string{"CIRCLECI": "true"}, want: "CircleCI"},
+		{name: "woodpecker", envs: map[string]string{"CI": "woodpecker"}, want: "Woodpecker CI"},
+		{name: "jenkins", envs: map[string]string{"JENKINS_URL": "http://jenkins.local"}, want: "Jenkins"},
+		{name: "none", envs: map[string]string{}, want: "generic CI"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearCIEnv(t)
+			for k, v := range tc.envs {
+				os.Setenv(k, v)
+			}
+			got := detectCIRunner().Name()
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGithubRunnerOpenEnvFile(t *testing.T) {
+	cases := []struct {
+		name       string
+		githubEnv  string
+		wantErr    error
+		createFile bool
+	}{
+		{
+			name:    "not defined",
+			wantErr: fmt.Errorf("GITHUB_ENV environment is not defined"),
+		},
+		{
+			name:      "cannot open file",
+			githubEnv: "./does-not-exist",
+			wantErr:   fmt.Errorf("cannot open file ./does-not-exist: open ./does-not-exist: no such file or directory"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("GITHUB_ENV", tc.githubEnv)
+			defer os.Unsetenv("GITHUB_ENV")
+			_, err := (&githubRunner{}).OpenEnvFile()
+			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGitlabRunnerOpenEnvFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		envs    map[string]string
+		wantErr error
+	}{
+		{
+			name:    "CI_JOB_NAME not defined",
+			envs:    map[string]string{},
+			wantErr: fmt.Errorf("CI_JOB_NAME environment is not defined"),
+		},
+		{
+			name:    "CI_PROJECT_PATH not defined",
+			envs:    map[string]string{"CI_JOB_NAME": "some_job"},
+			wantErr: fmt.Errorf("CI_PROJECT_PATH environment is not defined"),
+		},
+		{
+			name:    "cannot open file",
+			envs:    map[string]string{"CI_JOB_NAME": "some_job", "CI_PROJECT_PATH": "some_project"},
+			wantErr: fmt.Errorf("cannot open file /builds/some_project/some_job: open /builds/some_project/some_job: no such file or directory"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, v := range []string{"CI_JOB_NAME", "CI_PROJECT_PATH"} {
+				os.Unsetenv(v)
+			}
+			for k, v := range tc.envs {
+				os.Setenv(k, v)
+			}
+			_, err := (&gitlabRunner{}).OpenEnvFile()
+			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGithubRunnerFetchOIDCIDToken(t *testing.T) {
+	cases := []struct {
+		name     string
+		requrl   string
+		reqtoken string
+		client   httpClient
+		want     string
+		wantErr  error
+	}{
+		{
+			name:    "request env vars not defined",
+			wantErr: fmt.Errorf("ACTIONS_ID_TOKEN_REQUEST_URL/ACTIONS_ID_TOKEN_REQUEST_TOKEN are not defined; grant this job 'id-token: write' permission"),
+		},
+		{
+			name:     "happy path",
+			requrl:   "https://token.actions.githubusercontent.com/id-token",
+			reqtoken: "req-token",
+			client: &MockHttpClient{
+				response: &http.Response{
+					Status:     "200 OK",
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"value": "id-token"}`))),
+				},
+			},
+			want: "id-token",
+		},
+		{
+			name:     "bad request",
+			requrl:   "https://token.actions.githubusercontent.com/id-token",
+			reqtoken: "req-token",
+			client: &MockHttpClient{
+				response: &http.Response{Status: "400 Bad Request", StatusCode: 400, Body: io.NopCloser(bytes.NewReader(nil))},
+			},
+			wantErr: fmt.Errorf("GET https://token.actions.githubusercontent.com/id-token: 400 Bad Request"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", tc.requrl)
+			os.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", tc.reqtoken)
+			defer os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+			defer os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+
+			got, err := (&githubRunner{}).FetchOIDCIDToken(context.Background(), tc.client)
+			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGitlabRunnerFetchOIDCIDToken(t *testing.T) {
+	cases := []struct {
+		name    string
+		jwt     string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "not defined",
+			wantErr: fmt.Errorf("CI_JOB_JWT_V2 environment is not defined; configure an 'id_tokens:' block for this job"),
+		},
+		{
+			name: "happy path",
+			jwt:  "the-jwt",
+			want: "the-jwt",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("CI_JOB_JWT_V2", tc.jwt)
+			defer os.Unsetenv("CI_JOB_JWT_V2")
+
+			got, err := (&gitlabRunner{}).FetchOIDCIDToken(context.Background(), nil)
+			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGithubRunnerWriteStepSummary(t *testing.T) {
+	var summary runSummary
+	summary.add("folder1/secret1", "KEY1")
+
+	t.Run("writes markdown when set", func(t *testing.T) {
+		summaryFile := filepath.Join(t.TempDir(), "summary.md")
+		if err := os.WriteFile(summaryFile, nil, 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		os.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+		defer os.Unsetenv("GITHUB_STEP_SUMMARY")
+
+		if err := (&githubRunner{}).WriteStepSummary(summary); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := os.ReadFile(summaryFile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(got), "folder1/secret1") || !strings.Contains(string(got), "KEY1") {
+			t.Errorf("summary file missing expected content, got %q", string(got))
+		}
+	})
+
+	t.Run("untouched when GITHUB_STEP_SUMMARY unset", func(t *testing.T) {
+		os.Unsetenv("GITHUB_STEP_SUMMARY")
+		if err := (&githubRunner{}).WriteStepSummary(summary); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("untouched when SUMMARY=false", func(t *testing.T) {
+		summaryFile := filepath.Join(t.TempDir(), "summary.md")
+		if err := os.WriteFile(summaryFile, nil, 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		os.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+		os.Setenv("SUMMARY", "false")
+		defer os.Unsetenv("GITHUB_STEP_SUMMARY")
+		defer os.Unsetenv("SUMMARY")
+
+		if err := (&githubRunner{}).WriteStepSummary(summary); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := os.ReadFile(summaryFile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("want summary file untouched, got %q", string(got))
+		}
+	})
+}
+
+// TestShouldSetEnvWithoutEnvFileMechanism guards against silently honoring
+// SET_ENV on CIs that have no env-file mechanism to write to: ShouldSetEnv
+// must stay false regardless, or SET_ENV would be a no-op with no
+// diagnostic.
+func TestShouldSetEnvWithoutEnvFileMechanism(t *testing.T) {
+	os.Setenv("SET_ENV", "true")
+	defer os.Unsetenv("SET_ENV")
+
+	runners := []CIRunner{
+		&genericRunner{},
+		&bitbucketRunner{},
+		&woodpeckerRunner{},
+		&jenkinsRunner{},
+	}
+	for _, r := range runners {
+		if r.ShouldSetEnv() {
+			t.Errorf("%s: want ShouldSetEnv() false even with SET_ENV set, got true", r.Name())
+		}
+	}
+}
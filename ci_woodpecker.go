@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// woodpeckerRunner implements CIRunner for Woodpecker CI. Woodpecker sets
+// CI=woodpecker and, like Bitbucket, has no built-in cross-step env file.
+type woodpeckerRunner struct {
+	masker secretMasker
+}
+
+func (r *woodpeckerRunner) Name() string { return "Woodpecker CI" }
+
+func (r *woodpeckerRunner) Detect() bool {
+	return os.Getenv("CI") == "woodpecker"
+}
+
+// ShouldSetEnv always returns false: Woodpecker CI has no built-in
+// cross-step env file, so honoring SET_ENV here would silently export
+// nothing.
+func (r *woodpeckerRunner) ShouldSetEnv() bool {
+	return false
+}
+
+func (r *woodpeckerRunner) OpenEnvFile() (*os.File, error) {
+	return nil, nil
+}
+
+func (r *woodpeckerRunner) SetOutput(key, val string) {}
+
+func (r *woodpeckerRunner) ExportVar(envFile *os.File, key, val string) error {
+	return nil
+}
+
+func (r *woodpeckerRunner) Info(s string) {
+	fmt.Fprintln(r.masker.Wrap(os.Stdout), s)
+}
+
+func (r *woodpeckerRunner) Debug(s string) {
+	if os.Getenv("DEBUG") != "" {
+		fmt.Fprintf(r.masker.Wrap(os.Stdout), "[debug] %s\n", s)
+	}
+}
+
+func (r *woodpeckerRunner) Error(err error) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %v\n", err)
+}
+
+func (r *woodpeckerRunner) StringError(s string) {
+	fmt.Fprintf(r.masker.Wrap(os.Stdout), "[error] %s\n", s)
+}
+
+func (r *woodpeckerRunner) Notice(s string) {
+	r.Info(s)
+}
+
+func (r *woodpeckerRunner) MaskSecret(val string) {
+	r.masker.Register(val)
+}
+
+func (r *woodpeckerRunner) FetchOIDCIDToken(ctx context.Context, c httpClient) (string, error) {
+	return "", fmt.Errorf("OIDC authentication is not supported on %s", r.Name())
+}
+
+// WriteStepSummary is a no-op: Woodpecker CI has no job-summary mechanism.
+func (r *woodpeckerRunner) WriteStepSummary(summary runSummary) error {
+	return nil
+}
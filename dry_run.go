@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// dryRunMode selects how far run() goes in dry-run mode: dryRunOff runs
+// normally, dryRunValidate still fetches a token to confirm credentials
+// work, and dryRunParseOnly skips DSV entirely.
+type dryRunMode int
+
+const (
+	dryRunOff dryRunMode = iota
+	dryRunValidate
+	dryRunParseOnly
+)
+
+// dryRunModeFromEnv reads DRY_RUN ("true" or "parse-only"), defaulting to
+// dryRunOff for any other value, including unset.
+func dryRunModeFromEnv() dryRunMode {
+	switch os.Getenv("DRY_RUN") {
+	case "true":
+		return dryRunValidate
+	case "parse-only":
+		return dryRunParseOnly
+	default:
+		return dryRunOff
+	}
+}
+
+// dryRunEntry describes one secret that a real run would fetch and export,
+// without its value -- dry-run mode never calls DSV for secret data, so
+// there is never a value to withhold in the first place.
+type dryRunEntry struct {
+	Path      string `json:"path"`
+	DataKey   string `json:"dataKey"`
+	OutputKey string `json:"outputKey,omitempty"`
+	FilePath  string `json:"filePath,omitempty"`
+}
+
+// buildDryRunReport flattens retrieveData into a report sorted by path then
+// data key, so output is stable across runs despite map iteration order.
+func buildDryRunReport(retrieveData map[string]map[string]retrieveTarget) []dryRunEntry {
+	var entries []dryRunEntry
+	for path, dataMap := range retrieveData {
+		for dataKey, target := range dataMap {
+			entries = append(entries, dryRunEntry{
+				Path:      path,
+				DataKey:   dataKey,
+				OutputKey: target.Key,
+				FilePath:  target.FilePath,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].DataKey < entries[j].DataKey
+	})
+	return entries
+}
+
+// printDryRunReport writes entries to w as either plain text, one line per
+// entry, or, when format is "json", a single JSON array for downstream
+// pipeline tooling to consume.
+func printDryRunReport(w io.Writer, entries []dryRunEntry, format string) error {
+	if format == "json" {
+		return json.NewEncoder(w).Encode(entries)
+	}
+	for _, e := range entries {
+		target := e.OutputKey
+		if e.FilePath != "" {
+			target = "file:" + e.FilePath
+		}
+		if _, err := fmt.Fprintf(w, "%s %s -> %s\n", e.Path, e.DataKey, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
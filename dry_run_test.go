@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildDryRunReport(t *testing.T) {
+	retrieveData := map[string]map[string]retrieveTarget{
+		"folder1/secret1": {
+			"mykey1": {Key: "KEY1"},
+			"mykey2": {FilePath: "out/mykey2"},
+		},
+		"folder2/secret2": {
+			"mykey": {Key: "KEY3"},
+		},
+	}
+
+	want := []dryRunEntry{
+		{Path: "folder1/secret1", DataKey: "mykey1", OutputKey: "KEY1"},
+		{Path: "folder1/secret1", DataKey: "mykey2", FilePath: "out/mykey2"},
+		{Path: "folder2/secret2", DataKey: "mykey", OutputKey: "KEY3"},
+	}
+	got := buildDryRunReport(retrieveData)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestPrintDryRunReport(t *testing.T) {
+	entries := []dryRunEntry{
+		{Path: "folder1/secret1", DataKey: "mykey1", OutputKey: "KEY1"},
+		{Path: "folder1/secret1", DataKey: "mykey2", FilePath: "out/mykey2"},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printDryRunReport(&buf, entries, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "folder1/secret1 mykey1 -> KEY1\nfolder1/secret1 mykey2 -> file:out/mykey2\n"
+		if buf.String() != want {
+			t.Errorf("want %q, got %q", want, buf.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printDryRunReport(&buf, entries, "json"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []dryRunEntry
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %v", err)
+		}
+		if !reflect.DeepEqual(entries, got) {
+			t.Errorf("want %#v, got %#v", entries, got)
+		}
+	})
+}
+
+func TestDryRunModeFromEnv(t *testing.T) {
+	cases := []struct {
+		value string
+		want  dryRunMode
+	}{
+		{value: "", want: dryRunOff},
+		{value: "false", want: dryRunOff},
+		{value: "true", want: dryRunValidate},
+		{value: "parse-only", want: dryRunParseOnly},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			os.Setenv("DRY_RUN", tc.value)
+			defer os.Unsetenv("DRY_RUN")
+			if got := dryRunModeFromEnv(); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// failingHTTPClient fails the test if Do is ever called, so tests can
+// confirm dry-run modes never reach DSV.
+type failingHTTPClient struct{ t *testing.T }
+
+func (c *failingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.t.Fatalf("unexpected HTTP call to %s in dry-run mode", req.URL)
+	return nil, nil
+}
+
+func TestRunDryRunParseOnlyNeverCallsDSV(t *testing.T) {
+	os.Setenv("DRY_RUN", "parse-only")
+	defer os.Unsetenv("DRY_RUN")
+
+	ci := &genericRunner{}
+	retrieveData := map[string]map[string]retrieveTarget{
+		"folder1/secret1": {"mykey": {Key: "KEY1"}},
+	}
+	provider := &ClientCredentialsProvider{ClientID: "id", ClientSecret: "secret"}
+
+	err := run(context.Background(), ci, "test.example.com", provider, retrieveData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunDryRunValidateFetchesTokenOnly(t *testing.T) {
+	os.Setenv("DRY_RUN", "true")
+	defer os.Unsetenv("DRY_RUN")
+
+	ci := &genericRunner{}
+	retrieveData := map[string]map[string]retrieveTarget{
+		"folder1/secret1": {"mykey": {Key: "KEY1"}},
+	}
+
+	t.Run("auth failure surfaces as unable to get token", func(t *testing.T) {
+		provider := &stubTokenProvider{err: fmt.Errorf("bad credentials")}
+		err := run(context.Background(), ci, "test.example.com", provider, retrieveData)
+		if err == nil || err.Error() != "unable to get token" {
+			t.Errorf("want %q, got %v", "unable to get token", err)
+		}
+	})
+
+	t.Run("happy path never touches secret endpoints", func(t *testing.T) {
+		provider := &stubTokenProvider{token: "tok"}
+		err := run(context.Background(), ci, "test.example.com", provider, retrieveData)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+// stubTokenProvider lets dry-run tests control GetToken's outcome without
+// making a real HTTP call.
+type stubTokenProvider struct {
+	token string
+	err   error
+}
+
+func (p *stubTokenProvider) GetToken(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint string) (string, error) {
+	return p.token, p.err
+}
+
+// TestDryRunNeverLeaksSecretMaterial guards the core guarantee of dry-run
+// mode: it never contacts DSV for secret data, so there is never a value
+// for the report to leak, in either output format.
+func TestDryRunNeverLeaksSecretMaterial(t *testing.T) {
+	for _, mode := range []string{"true", "parse-only"} {
+		for _, format := range []string{"", "json"} {
+			t.Run(mode+"/"+format, func(t *testing.T) {
+				os.Setenv("DRY_RUN", mode)
+				os.Setenv("DRY_RUN_FORMAT", format)
+				defer os.Unsetenv("DRY_RUN")
+				defer os.Unsetenv("DRY_RUN_FORMAT")
+
+				r, w, _ := os.Pipe()
+				origStdout := os.Stdout
+				os.Stdout = w
+				defer func() { os.Stdout = origStdout }()
+
+				ci := &genericRunner{}
+				retrieveData := map[string]map[string]retrieveTarget{
+					"folder1/secret1": {"mykey": {Key: "KEY1"}},
+				}
+				provider := &stubTokenProvider{token: "tok"}
+
+				err := run(context.Background(), ci, "test.example.com", provider, retrieveData)
+				w.Close()
+				os.Stdout = origStdout
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				var buf bytes.Buffer
+				buf.ReadFrom(r)
+				if strings.Contains(buf.String(), "tok") {
+					t.Errorf("dry-run output unexpectedly contains secret material: %q", buf.String())
+				}
+			})
+		}
+	}
+}
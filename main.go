@@ -2,238 +2,236 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"path"
-	"regexp"
-	"strings"
+	"strconv"
 	"time"
 )
 
-// defaultTimeout defines default timeout for HTTP requests.
+// defaultTimeout defines the default per-attempt timeout for HTTP requests.
 const defaultTimeout = time.Second * 5
 
-var (
-	githubCI      = os.Getenv("GITHUB_ACTION") != ""
-	gitlabCI      = os.Getenv("GITLAB_CI") != ""
-	gitlabCIDebug = os.Getenv("GITLAB_CI_DEBUG") != ""
-)
+// defaultOverallTimeout bounds the whole run() call -- token fetch plus
+// every secret fetch and all their retries -- when TIMEOUT is unset.
+const defaultOverallTimeout = time.Second * 30
 
 func main() {
-	switch {
-	case githubCI:
-		info("🐣 Starting work with GitHub CI")
-	case gitlabCI:
-		info("🐣 Starting work with GitLab CI")
-	default:
-		stringError("🤡 Unknown CI server")
-		os.Exit(1)
-	}
+	ci := detectCIRunner()
+	ci.Info(fmt.Sprintf("🐣 Starting work with %s", ci.Name()))
 
 	// Tenant domain name (e.g. example.secretsvaultcloud.com).
 	domain := os.Getenv("DOMAIN")
 	if domain == "" {
-		stringError("DOMAIN variable must be specified")
-		os.Exit(1)
-	}
-	// Client ID for authentication.
-	clientId := os.Getenv("CLIENT_ID")
-	if clientId == "" {
-		stringError("CLIENT_ID variable must be specified")
+		ci.StringError("DOMAIN variable must be specified")
 		os.Exit(1)
 	}
-	// Client Secret for authentication.
-	clientSecret := os.Getenv("CLIENT_SECRET")
-	if clientSecret == "" {
-		stringError("CLIENT_SECRET variable must be specified")
+	// How to authenticate to DSV: "client_credentials" (default) or "oidc" to
+	// exchange this CI runner's own OIDC ID token for an access token.
+	authMethod := os.Getenv("AUTH_METHOD")
+	if authMethod == "" {
+		authMethod = "client_credentials"
+	}
+	var provider tokenProvider
+	switch authMethod {
+	case "client_credentials":
+		clientId := os.Getenv("CLIENT_ID")
+		if clientId == "" {
+			ci.StringError("CLIENT_ID variable must be specified")
+			os.Exit(1)
+		}
+		clientSecret := os.Getenv("CLIENT_SECRET")
+		if clientSecret == "" {
+			ci.StringError("CLIENT_SECRET variable must be specified")
+			os.Exit(1)
+		}
+		provider = &ClientCredentialsProvider{ClientID: clientId, ClientSecret: clientSecret}
+	case "oidc":
+		provider = &OIDCProvider{Runner: ci}
+	default:
+		ci.StringError(fmt.Sprintf("unknown AUTH_METHOD '%s': must be 'client_credentials' or 'oidc'", authMethod))
 		os.Exit(1)
 	}
-	// Data to retrieve from DSV in format `<path> <data key> as <output key>`.
+	// Data to retrieve from DSV in format `<path> <data key> as <output key>`,
+	// optionally followed by `file:<path>` to write that key to a file
+	// instead of an env var/output.
 	retrieve := os.Getenv("RETRIEVE")
 	if retrieve == "" {
-		stringError("RETRIEVE variable must be specified")
+		ci.StringError("RETRIEVE variable must be specified")
 		os.Exit(1)
 	}
-	// Set environment variables in GITHUB. Required GITHUB_ENV environment variable to be a valid path to a file.
-	setEnv := false
-	if (githubCI && os.Getenv("SET_ENV") != "") || gitlabCI {
-		setEnv = true
-	}
-	retrieveData, err := parseRetrieveFlag(retrieve)
+	// Directory to write every key without an explicit file: target into,
+	// instead of an env var/output.
+	retrieveToFileDir := os.Getenv("RETRIEVE_TO_FILE")
+	retrieveData, err := parseRetrieveFlag(retrieve, retrieveToFileDir)
 	if err != nil {
-		printError(err)
+		ci.Error(err)
 		os.Exit(1)
 	}
-	if err := run(domain, clientId, clientSecret, setEnv, retrieveData); err != nil {
-		printError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), overallTimeoutFromEnv())
+	defer cancel()
+
+	if err := run(ctx, ci, domain, provider, retrieveData); err != nil {
+		ci.Error(err)
 		os.Exit(1)
 	}
 }
 
-func run(domain, clientId, clientSecret string, setEnv bool, retrieveData map[string]map[string]string) error {
+// overallTimeoutFromEnv returns the TIMEOUT env var as a duration -- either
+// a Go duration string (e.g. "45s") or a plain number of seconds -- falling
+// back to defaultOverallTimeout when unset or invalid.
+func overallTimeoutFromEnv() time.Duration {
+	v := os.Getenv("TIMEOUT")
+	if v == "" {
+		return defaultOverallTimeout
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return defaultOverallTimeout
+}
+
+func run(ctx context.Context, ci CIRunner, domain string, provider tokenProvider, retrieveData map[string]map[string]retrieveTarget) error {
 	apiEndpoint := fmt.Sprintf("https://%s/v1", domain)
 	httpClient := &http.Client{Timeout: defaultTimeout}
+	retryCfg := retryConfigFromEnv()
+
+	if mode := dryRunModeFromEnv(); mode != dryRunOff {
+		ci.Info("🧪 Dry run: reporting what would be fetched, without retrieving any secret values")
+		if mode == dryRunValidate {
+			ci.Info("🔑 Validating credentials...")
+			if _, err := provider.GetToken(ctx, httpClient, retryCfg, apiEndpoint); err != nil {
+				ci.Debug(fmt.Sprintf("authentication failed: %v", err))
+				return fmt.Errorf("unable to get token")
+			}
+			ci.Debug("Got access token")
+		}
+		return printDryRunReport(os.Stdout, buildDryRunReport(retrieveData), os.Getenv("DRY_RUN_FORMAT"))
+	}
 
-	info("🔑 Fetching access token...")
-	token, err := dsvGetToken(httpClient, apiEndpoint, clientId, clientSecret)
+	ci.Info("🔑 Fetching access token...")
+	token, err := provider.GetToken(ctx, httpClient, retryCfg, apiEndpoint)
 	if err != nil {
-		debugf("authentication failed: %v", err)
+		ci.Debug(fmt.Sprintf("authentication failed: %v", err))
 		return fmt.Errorf("unable to get token")
 	}
-	debug("Got access token")
+	ci.Debug("Got access token")
 
-	envFile, err := openEnvFile(setEnv)
-	if err != nil {
-		return err
+	setEnv := ci.ShouldSetEnv()
+	var envFile *os.File
+	if setEnv {
+		envFile, err = ci.OpenEnvFile()
+		if err != nil {
+			return err
+		}
+		defer envFile.Close()
 	}
-	defer envFile.Close()
 
-	info("✨ Fetching secret(s) from DSV...")
-	debugf("RETRIEVE: %#v\n", retrieveData)
+	var summary runSummary
+
+	ci.Info("✨ Fetching secret(s) from DSV...")
+	ci.Debug(fmt.Sprintf("RETRIEVE: %#v", retrieveData))
 	for path, dataMap := range retrieveData {
-		debugf("Fetching secret at path %q", path)
+		ci.Debug(fmt.Sprintf("Fetching secret at path %q", path))
 
-		secret, err := dsvGetSecret(httpClient, apiEndpoint, token, path)
+		secret, err := dsvGetSecret(ctx, httpClient, retryCfg, apiEndpoint, token, path)
 		if err != nil {
-			debugf("failed to fetch secret from DSV: %v", err)
+			ci.Debug(fmt.Sprintf("failed to fetch secret from DSV: %v", err))
 			return fmt.Errorf("unable to get secret")
 		}
-		debugf("Got secret at path %q", path)
+		ci.Debug(fmt.Sprintf("Got secret at path %q", path))
 
 		secretData, ok := secret["data"].(map[string]interface{})
 		if !ok {
-			debugf("cannot get secret data from '%s' secret", path)
+			ci.Debug(fmt.Sprintf("cannot get secret data from '%s' secret", path))
 			return fmt.Errorf("cannot parse secret")
 		}
 
-		for secretDataKey, outputKey := range dataMap {
-			debugf("Getting %s field from secret at path %s", secretDataKey, path)
+		for secretDataKey, target := range dataMap {
+			ci.Debug(fmt.Sprintf("Getting %s field from secret at path %s", secretDataKey, path))
 			secretValue, ok := secretData[secretDataKey].(string)
 			if !ok {
-				debugf("cannot get '%s' from '%s' secret data", secretDataKey, path)
+				ci.Debug(fmt.Sprintf("cannot get '%s' from '%s' secret data", secretDataKey, path))
 				return fmt.Errorf("cannot parse secret")
 			}
-			debugf("Got %s field from secret at path %s", secretDataKey, path)
+			ci.Debug(fmt.Sprintf("Got %s field from secret at path %s", secretDataKey, path))
+			ci.MaskSecret(secretValue)
 
-			if githubCI {
-				actionSetOutput(outputKey, secretValue)
-				debugf("Output %s has been set as value '%s' from secret at path %s",
-					strings.ToUpper(outputKey), secretDataKey, path)
+			if err := deliverSecret(ci, envFile, setEnv, path, secretDataKey, target, secretValue); err != nil {
+				return err
 			}
-			if setEnv {
-				if err := exportVariable(envFile, outputKey, secretValue); err != nil {
-					debugf("exporting variable error: %v", err)
-					return fmt.Errorf("cannot set environment variable")
-				}
-				debugf("Environment variable %s has been set as value %s from %s secret",
-					strings.ToUpper(outputKey), secretDataKey, path)
+
+			outputKey := target.Key
+			if target.FilePath != "" {
+				outputKey = "file:" + target.FilePath
 			}
+			summary.add(path, outputKey)
 		}
 	}
-	return nil
-}
-
-func parseRetrieveFlag(retrieve string) (map[string]map[string]string, error) {
-	pathRegexp := regexp.MustCompile(`^[a-zA-Z0-9:\/@\+._-]+$`)
-	whitespaces := regexp.MustCompile(`\s+`)
-
-	result := make(map[string]map[string]string)
 
-	for _, row := range strings.Split(retrieve, "\n") {
-		row = strings.TrimSpace(row)
-		if row == "" {
-			continue
-		}
-		row = whitespaces.ReplaceAllString(row, " ")
-
-		tokens := strings.Split(row, " ")
-
-		if len(tokens) != 4 {
-			return nil, fmt.Errorf("failed to parse '%s'. "+
-				"each 'retrieve' row must contain '<secret path> <secret data key> as <output key>' separated by spaces and/or tabs", row)
-		}
-
-		var (
-			path      = tokens[0]
-			dataKey   = tokens[1]
-			outputKey = tokens[3]
-		)
-		if !pathRegexp.MatchString(path) {
-			return nil, fmt.Errorf("failed to parse secret path '%s': "+
-				"secret path may contain only letters, numbers, underscores, dashes, @, pluses and periods separated by colon or slash",
-				path)
-		}
-
-		if _, ok := result[path]; !ok {
-			result[path] = make(map[string]string)
-		}
-		result[path][dataKey] = outputKey
+	// A failed step summary write is cosmetic -- every secret above has
+	// already been fetched, masked, and delivered -- so log it rather than
+	// failing an otherwise successful run.
+	if err := ci.WriteStepSummary(summary); err != nil {
+		ci.Debug(fmt.Sprintf("failed to write step summary: %v", err))
+		ci.Error(fmt.Errorf("could not write step summary: %v", err))
 	}
-
-	return result, nil
+	ci.Notice(fmt.Sprintf("✨ Fetched %d secret(s) from %d path(s)", summary.totalKeys(), len(summary.Paths)))
+	return nil
 }
 
 type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-func dsvGetToken(c httpClient, apiEndpoint, cid, csecret string) (string, error) {
-	body := []byte(fmt.Sprintf(
+func dsvGetToken(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint, cid, csecret string) (string, error) {
+	endpoint := apiEndpoint + "/token"
+	reqBody := []byte(fmt.Sprintf(
 		`{"grant_type":"client_credentials","client_id":"%s","client_secret":"%s"}`,
 		cid, csecret,
 	))
-	endpoint := apiEndpoint + "/token"
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("could not build request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Delinea-DSV-Client", "gh-action")
 
-	resp, err := c.Do(req)
+	resp, err := doWithRetry(ctx, c, cfg, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("could not build request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Delinea-DSV-Client", "gh-action")
+		return req, nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("API call failed: %v", err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("POST %s: %s", endpoint, resp.Status)
-	}
-
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("could not read response body: %v", err)
-	}
-	tokenRespData := make(map[string]interface{})
-	err = json.Unmarshal(body, &tokenRespData)
-	if err != nil {
-		return "", fmt.Errorf("could not unmarshal response body: %v", err)
-	}
-
-	token, strExists := tokenRespData["accessToken"].(string)
-	if !strExists {
-		return "", fmt.Errorf("could not read access token from response")
-	}
-	return token, nil
+	return parseTokenResponse(resp, endpoint, "check CLIENT_ID/CLIENT_SECRET")
 }
 
-func dsvGetSecret(c httpClient, apiEndpoint, accessToken, secretPath string) (map[string]interface{}, error) {
+func dsvGetSecret(ctx context.Context, c httpClient, cfg retryConfig, apiEndpoint, accessToken, secretPath string) (map[string]interface{}, error) {
 	endpoint := apiEndpoint + "/secrets/" + secretPath
-	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("could not build request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Delinea-DSV-Client", "gh-action")
-	req.Header.Set("Authorization", accessToken)
 
-	resp, err := c.Do(req)
+	resp, err := doWithRetry(ctx, c, cfg, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not build request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Delinea-DSV-Client", "gh-action")
+		req.Header.Set("Authorization", accessToken)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("API call failed: %v", err)
 	}
+	if isAuthFailure(resp.StatusCode) {
+		return nil, fmt.Errorf("GET %s: %s (check CLIENT_ID/CLIENT_SECRET)", endpoint, resp.Status)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("GET %s: %s", endpoint, resp.Status)
 	}
@@ -249,83 +247,3 @@ func dsvGetSecret(c httpClient, apiEndpoint, accessToken, secretPath string) (ma
 	}
 	return secret, nil
 }
-
-func debug(s string) {
-	if githubCI {
-		fmt.Printf("::debug::%s\n", s)
-	} else if gitlabCI && gitlabCIDebug {
-		fmt.Printf("##[debug]\x1b[94m%s\x1b[0m\n", s)
-	}
-}
-
-func debugf(format string, args ...interface{}) {
-	debug(fmt.Sprintf(format, args...))
-}
-
-func info(s string) {
-	if githubCI {
-		fmt.Println(s)
-	} else if gitlabCI {
-		fmt.Printf("\x1b[92m%s\x1b[0m\n", s)
-	}
-
-}
-
-func printError(err error) {
-	if githubCI {
-		fmt.Printf("::error::%v\n", err)
-	} else if gitlabCI {
-		fmt.Printf("\x1b[91m%v\x1b[0m\n", err)
-	}
-}
-
-func stringError(s string) {
-	if githubCI {
-		fmt.Printf("::error::%s\n", s)
-	} else if gitlabCI {
-		fmt.Printf("\x1b[91m%s\x1b[0m\n", s)
-	}
-}
-
-func actionSetOutput(key, val string) {
-	fmt.Printf("::set-output name=%s::%s\n", key, val)
-}
-
-func openEnvFile(setEnv bool) (*os.File, error) {
-	var (
-		envFile *os.File
-		err     error
-	)
-	if gitlabCI {
-		jobName := os.Getenv("CI_JOB_NAME")
-		if jobName == "" {
-			return nil, fmt.Errorf("CI_JOB_NAME environment is not defined")
-		}
-		pwd := os.Getenv("CI_PROJECT_PATH")
-		if pwd == "" {
-			return nil, fmt.Errorf("CI_PROJECT_PATH environment is not defined")
-		}
-		envFileName := path.Join("/builds/", pwd, jobName)
-		envFile, err = os.OpenFile(envFileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
-		if err != nil {
-			return nil, fmt.Errorf("cannot open file %s: %v", envFileName, err)
-		}
-	} else if githubCI && setEnv {
-		envFileName := os.Getenv("GITHUB_ENV")
-		if envFileName == "" {
-			return nil, fmt.Errorf("GITHUB_ENV environment is not defined")
-		}
-		envFile, err = os.OpenFile(envFileName, os.O_APPEND|os.O_WRONLY, 0600)
-		if err != nil {
-			return nil, fmt.Errorf("cannot open file %s: %v", envFileName, err)
-		}
-	}
-	return envFile, nil
-}
-
-func exportVariable(envFile *os.File, key, val string) error {
-	if _, err := envFile.WriteString(fmt.Sprintf("%s=%s\n", strings.ToUpper(key), val)); err != nil {
-		return fmt.Errorf("could not update %s environment file: %v", envFile.Name(), err)
-	}
-	return nil
-}
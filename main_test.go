@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,11 @@ import (
 	"testing"
 )
 
+// noRetryConfig is used in tests that exercise a single HTTP attempt, so
+// that a transient-looking mock response (e.g. a network error) doesn't
+// retry and change the call count the test asserts on.
+var noRetryConfig = retryConfig{MaxRetries: 0}
+
 type MockHttpClient struct {
 	response *http.Response
 	err      error
@@ -52,6 +58,7 @@ func TestMain(t *testing.T) {
 		},
 	}
 	for _, tc := range cases {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			cmd := exec.Command(os.Args[0], "-test.run=TestMain")
@@ -64,72 +71,6 @@ func TestMain(t *testing.T) {
 	}
 }
 
-func TestParseRetrieveFlag(t *testing.T) {
-	cases := []struct {
-		name     string
-		retrieve string
-		want     map[string]map[string]string
-		wantErr  error
-	}{
-		{
-			name:     "empty string",
-			retrieve: "",
-			want:     make(map[string]map[string]string),
-			wantErr:  nil,
-		},
-		{
-			name: "happy path",
-			retrieve: `
-			folder1/folder2/secret1 mykey1 as key1
-			folder1/folder2/secret1 mykey2 as key2
-			folder1/folder2/secret2 mykey as key3
-			`,
-			want: map[string]map[string]string{
-				"folder1/folder2/secret1": {
-					"mykey1": "key1",
-					"mykey2": "key2",
-				},
-				"folder1/folder2/secret2": {
-					"mykey": "key3",
-				},
-			},
-			wantErr: nil,
-		},
-		{
-			name: "secret path validation",
-			retrieve: `
-			folder@/folder-/_secret_	mykey1 as key1
-			secret$ 					mykey2 as key2
-			`,
-			want:    nil,
-			wantErr: fmt.Errorf("failed to parse secret path 'secret$': secret path may contain only letters, numbers, underscores, dashes, @, pluses and periods separated by colon or slash"),
-		},
-		{
-			name:     "too many args",
-			retrieve: `arg1 arg2 as arg3 arg4`,
-			want:     nil,
-			wantErr:  fmt.Errorf("failed to parse 'arg1 arg2 as arg3 arg4'. each 'retrieve' row must contain '<secret path> <secret data key> as <output key>' separated by spaces and/or tabs"),
-		},
-		{
-			name:     "less args",
-			retrieve: `arg1 arg2`,
-			want:     nil,
-			wantErr:  fmt.Errorf("failed to parse 'arg1 arg2'. each 'retrieve' row must contain '<secret path> <secret data key> as <output key>' separated by spaces and/or tabs"),
-		},
-	}
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			result, err := parseRetrieveFlag(tc.retrieve)
-			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
-				t.Errorf("want error %v, got %v", tc.wantErr, err)
-			}
-			if !reflect.DeepEqual(tc.want, result) {
-				t.Errorf("want %v, got %v", tc.want, result)
-			}
-		})
-	}
-}
-
 func TestDsvGetToken(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -239,7 +180,7 @@ func TestDsvGetToken(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := dsvGetToken(tc.client, tc.apiEndpoint, tc.cid, tc.csecret)
+			result, err := dsvGetToken(context.Background(), tc.client, noRetryConfig, tc.apiEndpoint, tc.cid, tc.csecret)
 			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
 				t.Errorf("want error %v, got %v", tc.wantErr, err)
 			}
@@ -327,7 +268,7 @@ func TestDsvGetSecret(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := dsvGetSecret(tc.client, tc.apiEndpoint, tc.accessToken, tc.secretPath)
+			result, err := dsvGetSecret(context.Background(), tc.client, noRetryConfig, tc.apiEndpoint, tc.accessToken, tc.secretPath)
 			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
 				t.Errorf("want error %v, got %v", tc.wantErr, err)
 			}
@@ -337,80 +278,3 @@ func TestDsvGetSecret(t *testing.T) {
 		})
 	}
 }
-
-func TestOpenEnvFile(t *testing.T) {
-	cases := []struct {
-		name     string
-		envs     map[string]string
-		gitlabCI bool
-		githubCI bool
-		wantErr  error
-	}{
-		{
-			name: "gitlabCI: no variable set",
-			envs: map[string]string{
-				"CI_JOB_NAME":     "",
-				"CI_PROJECT_PATH": "",
-				"GITHUB_ENV":      "",
-			},
-			gitlabCI: true,
-			wantErr:  fmt.Errorf("CI_JOB_NAME environment is not defined"),
-		},
-		{
-			name: "githubCI: no variable set",
-			envs: map[string]string{
-				"CI_JOB_NAME":     "",
-				"CI_PROJECT_PATH": "",
-				"GITHUB_ENV":      "",
-			},
-			githubCI: true,
-			wantErr:  fmt.Errorf("GITHUB_ENV environment file is not defined"),
-		},
-		{
-			name: "githubCI: cannot open file",
-			envs: map[string]string{
-				"CI_JOB_NAME":     "",
-				"CI_PROJECT_PATH": "",
-				"GITHUB_ENV":      "./myfile",
-			},
-			githubCI: true,
-			wantErr:  fmt.Errorf("cannot open file ./myfile: open ./myfile: no such file or directory"),
-		},
-		{
-			name: "gitlabCI: no CI_PROJECT_PATH",
-			envs: map[string]string{
-				"CI_JOB_NAME":     "some_job",
-				"CI_PROJECT_PATH": "",
-				"GITHUB_ENV":      "",
-			},
-			gitlabCI: true,
-			wantErr:  fmt.Errorf("CI_PROJECT_PATH environment is not defined"),
-		},
-		{
-			name: "gitlabCI: cannot open file",
-			envs: map[string]string{
-				"CI_JOB_NAME":     "some_job",
-				"CI_PROJECT_PATH": "some_project",
-				"GITHUB_ENV":      "",
-			},
-			gitlabCI: true,
-			wantErr:  fmt.Errorf("cannot open file /builds/some_project/some_job: open /builds/some_project/some_job: no such file or directory"),
-		},
-	}
-	limit := make(chan struct{}, 1)
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			limit <- struct{}{}
-			githubCI = tc.githubCI
-			gitlabCI = tc.gitlabCI
-			for key, val := range tc.envs {
-				os.Setenv(key, val)
-			}
-			_, err := openEnvFile(true)
-			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
-				t.Errorf("want error %v, got %v", tc.wantErr, err)
-			}
-			<-limit
-		})
-	}
-}
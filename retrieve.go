@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// retrieveTarget describes where a single secret data key's value should be
+// delivered: as an env var/step output named Key, or as a file written to
+// FilePath (set when the row used the `file:<path>` syntax, or when
+// RETRIEVE_TO_FILE names a directory every key should be written into).
+type retrieveTarget struct {
+	Key      string
+	FilePath string
+}
+
+var (
+	retrievePathRegexp  = regexp.MustCompile(`^[a-zA-Z0-9:\/@\+._-]+$`)
+	retrieveWhitespaces = regexp.MustCompile(`\s+`)
+)
+
+// parseRetrieveFlag parses the RETRIEVE variable, one row per secret data
+// key in the format `<secret path> <secret data key> as <output key>`,
+// optionally followed by `file:<path>` to write that key to a file instead
+// of an env var/output. retrieveToFileDir, when non-empty, is the directory
+// every row without an explicit file: target is written into instead.
+func parseRetrieveFlag(retrieve, retrieveToFileDir string) (map[string]map[string]retrieveTarget, error) {
+	result := make(map[string]map[string]retrieveTarget)
+
+	for _, row := range strings.Split(retrieve, "\n") {
+		row = strings.TrimSpace(row)
+		if row == "" {
+			continue
+		}
+		row = retrieveWhitespaces.ReplaceAllString(row, " ")
+
+		tokens := strings.Split(row, " ")
+
+		if len(tokens) != 4 && len(tokens) != 5 {
+			return nil, fmt.Errorf("failed to parse '%s'. "+
+				"each 'retrieve' row must contain '<secret path> <secret data key> as <output key>' "+
+				"optionally followed by 'file:<path>', separated by spaces and/or tabs", row)
+		}
+
+		var (
+			path      = tokens[0]
+			dataKey   = tokens[1]
+			outputKey = tokens[3]
+		)
+		if !retrievePathRegexp.MatchString(path) {
+			return nil, fmt.Errorf("failed to parse secret path '%s': "+
+				"secret path may contain only letters, numbers, underscores, dashes, @, pluses and periods separated by colon or slash",
+				path)
+		}
+
+		target := retrieveTarget{Key: outputKey}
+		if len(tokens) == 5 {
+			filePath, ok := strings.CutPrefix(tokens[4], "file:")
+			if !ok {
+				return nil, fmt.Errorf("failed to parse '%s': expected a 'file:<path>' target, got '%s'", row, tokens[4])
+			}
+			if err := validateFileOutputPath(filePath); err != nil {
+				return nil, err
+			}
+			target.FilePath = filePath
+		} else if retrieveToFileDir != "" {
+			if err := validateFileOutputPath(outputKey); err != nil {
+				return nil, err
+			}
+			target.FilePath = filepath.Join(retrieveToFileDir, outputKey)
+		}
+
+		if _, ok := result[path]; !ok {
+			result[path] = make(map[string]retrieveTarget)
+		}
+		result[path][dataKey] = target
+	}
+
+	return result, nil
+}
+
+// deliverSecret sends secretValue to its configured target: written to a
+// file when target.FilePath is set, or exposed as a step output and,
+// if setEnv, appended to envFile.
+func deliverSecret(ci CIRunner, envFile *os.File, setEnv bool, path, secretDataKey string, target retrieveTarget, secretValue string) error {
+	if target.FilePath != "" {
+		if err := os.WriteFile(target.FilePath, []byte(secretValue), 0600); err != nil {
+			ci.Debug(fmt.Sprintf("file output error: %v", err))
+			return fmt.Errorf("cannot write secret to file")
+		}
+		ci.Debug(fmt.Sprintf("%s field from secret at path %s has been written to file %s",
+			secretDataKey, path, target.FilePath))
+		return nil
+	}
+
+	ci.SetOutput(target.Key, secretValue)
+	ci.Debug(fmt.Sprintf("Output %s has been set as value '%s' from secret at path %s",
+		strings.ToUpper(target.Key), secretDataKey, path))
+
+	if setEnv {
+		if err := ci.ExportVar(envFile, target.Key, secretValue); err != nil {
+			ci.Debug(fmt.Sprintf("exporting variable error: %v", err))
+			return fmt.Errorf("cannot set environment variable")
+		}
+		ci.Debug(fmt.Sprintf("Environment variable %s has been set as value %s from %s secret",
+			strings.ToUpper(target.Key), secretDataKey, path))
+	}
+	return nil
+}
+
+// validateFileOutputPath rejects file: targets that could escape the
+// intended output location via a ".." path segment. Both absolute paths
+// (e.g. /etc/secrets/cert.pem) and workspace-relative paths (e.g.
+// certs/cert.pem) are otherwise accepted.
+func validateFileOutputPath(p string) error {
+	if p == "" {
+		return fmt.Errorf("file output path must not be empty")
+	}
+	for _, part := range strings.Split(filepath.ToSlash(p), "/") {
+		if part == ".." {
+			return fmt.Errorf("file output path '%s' must not contain '..'", p)
+		}
+	}
+	return nil
+}
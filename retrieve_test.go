@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRetrieveFlag(t *testing.T) {
+	cases := []struct {
+		name              string
+		retrieve          string
+		retrieveToFileDir string
+		want              map[string]map[string]retrieveTarget
+		wantErr           error
+	}{
+		{
+			name:     "empty string",
+			retrieve: "",
+			want:     make(map[string]map[string]retrieveTarget),
+			wantErr:  nil,
+		},
+		{
+			name: "happy path",
+			retrieve: `
+			folder1/folder2/secret1 mykey1 as key1
+			folder1/folder2/secret1 mykey2 as key2
+			folder1/folder2/secret2 mykey as key3
+			`,
+			want: map[string]map[string]retrieveTarget{
+				"folder1/folder2/secret1": {
+					"mykey1": {Key: "key1"},
+					"mykey2": {Key: "key2"},
+				},
+				"folder1/folder2/secret2": {
+					"mykey": {Key: "key3"},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "secret path validation",
+			retrieve: `
+			folder@/folder-/_secret_	mykey1 as key1
+			secret$ 					mykey2 as key2
+			`,
+			want:    nil,
+			wantErr: fmt.Errorf("failed to parse secret path 'secret$': secret path may contain only letters, numbers, underscores, dashes, @, pluses and periods separated by colon or slash"),
+		},
+		{
+			name:     "too many args",
+			retrieve: `arg1 arg2 as arg3 arg4 arg5`,
+			want:     nil,
+			wantErr:  fmt.Errorf("failed to parse 'arg1 arg2 as arg3 arg4 arg5'. each 'retrieve' row must contain '<secret path> <secret data key> as <output key>' optionally followed by 'file:<path>', separated by spaces and/or tabs"),
+		},
+		{
+			name:     "5th token not a file target",
+			retrieve: `arg1 arg2 as arg3 arg4`,
+			want:     nil,
+			wantErr:  fmt.Errorf("failed to parse 'arg1 arg2 as arg3 arg4': expected a 'file:<path>' target, got 'arg4'"),
+		},
+		{
+			name:     "less args",
+			retrieve: `arg1 arg2`,
+			want:     nil,
+			wantErr:  fmt.Errorf("failed to parse 'arg1 arg2'. each 'retrieve' row must contain '<secret path> <secret data key> as <output key>' optionally followed by 'file:<path>', separated by spaces and/or tabs"),
+		},
+		{
+			name:     "file target",
+			retrieve: `folder1/secret1 mykey as key1 file:/etc/secrets/key1`,
+			want: map[string]map[string]retrieveTarget{
+				"folder1/secret1": {
+					"mykey": {Key: "key1", FilePath: "/etc/secrets/key1"},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "file target traversal rejected",
+			retrieve: `folder1/secret1 mykey as key1 file:../../etc/passwd`,
+			want:     nil,
+			wantErr:  fmt.Errorf("file output path '../../etc/passwd' must not contain '..'"),
+		},
+		{
+			name:              "retrieve to file dir applies to rows without explicit target",
+			retrieve:          "folder1/secret1 mykey as key1",
+			retrieveToFileDir: "/out",
+			want: map[string]map[string]retrieveTarget{
+				"folder1/secret1": {
+					"mykey": {Key: "key1", FilePath: "/out/key1"},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:              "explicit file target takes precedence over retrieve to file dir",
+			retrieve:          "folder1/secret1 mykey as key1 file:/custom/path",
+			retrieveToFileDir: "/out",
+			want: map[string]map[string]retrieveTarget{
+				"folder1/secret1": {
+					"mykey": {Key: "key1", FilePath: "/custom/path"},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:              "retrieve to file dir traversal via output key rejected",
+			retrieve:          "folder1/secret1 mykey as ../../tmp/evil",
+			retrieveToFileDir: "/builds/out",
+			want:              nil,
+			wantErr:           fmt.Errorf("file output path '../../tmp/evil' must not contain '..'"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := parseRetrieveFlag(tc.retrieve, tc.retrieveToFileDir)
+			if (tc.wantErr != nil && tc.wantErr.Error() != err.Error()) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(tc.want, result) {
+				t.Errorf("want %v, got %v", tc.want, result)
+			}
+		})
+	}
+}
+
+func TestValidateFileOutputPath(t *testing.T) {
+	cases := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{name: "empty", path: "", wantErr: fmt.Errorf("file output path must not be empty")},
+		{name: "absolute", path: "/etc/secrets/cert.pem", wantErr: nil},
+		{name: "workspace relative", path: "certs/cert.pem", wantErr: nil},
+		{name: "traversal", path: "../secret", wantErr: fmt.Errorf("file output path '../secret' must not contain '..'")},
+		{name: "traversal nested", path: "a/../../b", wantErr: fmt.Errorf("file output path 'a/../../b' must not contain '..'")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFileOutputPath(tc.path)
+			if (tc.wantErr != nil && (err == nil || tc.wantErr.Error() != err.Error())) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDeliverSecretWritesFile(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "secret.pem")
+	secretValue := "-----BEGIN CERTIFICATE-----"
+
+	ci := &genericRunner{}
+	target := retrieveTarget{Key: "unused", FilePath: outPath}
+	if err := deliverSecret(ci, nil, false, "folder1/secret1", "mykey", target, secretValue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != secretValue {
+		t.Errorf("want %q, got %q", secretValue, string(got))
+	}
+	info, err := os.Stat(outPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("want mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestDeliverSecretFileErrorOnMissingDir(t *testing.T) {
+	ci := &genericRunner{}
+	target := retrieveTarget{FilePath: filepath.Join(t.TempDir(), "missing", "secret.pem")}
+	err := deliverSecret(ci, nil, false, "folder1/secret1", "mykey", target, "val")
+	if err == nil || err.Error() != "cannot write secret to file" {
+		t.Errorf("want error 'cannot write secret to file', got %v", err)
+	}
+}
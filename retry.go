@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// retryConfig controls how dsvGetToken/dsvGetSecret retry transient
+// failures: network errors, 5xx responses, and 429 Too Many Requests.
+type retryConfig struct {
+	MaxRetries  int
+	BackoffBase time.Duration
+}
+
+// defaultRetryConfig is used when RETRY_MAX/RETRY_BACKOFF_MS are unset.
+var defaultRetryConfig = retryConfig{MaxRetries: 3, BackoffBase: 500 * time.Millisecond}
+
+// retryConfigFromEnv reads RETRY_MAX and RETRY_BACKOFF_MS, falling back to
+// defaultRetryConfig for values that are unset or not a non-negative int.
+func retryConfigFromEnv() retryConfig {
+	cfg := defaultRetryConfig
+	if v := os.Getenv("RETRY_MAX"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxRetries = n
+		}
+	}
+	if v := os.Getenv("RETRY_BACKOFF_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.BackoffBase = time.Duration(n) * time.Millisecond
+		}
+	}
+	return cfg
+}
+
+// isRetryableStatus reports whether an HTTP response status is worth
+// retrying: 429 Too Many Requests, or any 5xx.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// isAuthFailure reports whether status indicates the credentials themselves
+// were rejected. Retrying an auth failure just repeats the same outcome, so
+// doWithRetry treats it as a circuit breaker: abort immediately instead of
+// spending the retry budget.
+func isAuthFailure(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// maxBackoffShift caps how far backoffDelay will left-shift cfg.BackoffBase.
+// attempt is bounded by cfg.MaxRetries, which retryConfigFromEnv takes
+// straight from RETRY_MAX with no upper limit, so without this cap a large
+// RETRY_MAX (combined with a sizable RETRY_BACKOFF_MS) could shift the delay
+// into overflow and wrap it to a negative time.Duration.
+const maxBackoffShift = 20
+
+// maxBackoffDelay is the ceiling backoffDelay's exponential backoff ever
+// returns, regardless of cfg.BackoffBase or attempt.
+const maxBackoffDelay = 5 * time.Minute
+
+// backoffDelay computes the wait before the next attempt: the server's
+// Retry-After header when present on resp, otherwise an exponential backoff
+// from cfg.BackoffBase with full jitter.
+func backoffDelay(cfg retryConfig, attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if ra := resp.Header.Get("Retry-After"); ra != "" {
+			if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+				return time.Duration(secs) * time.Second
+			}
+		}
+	}
+	shift := attempt
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	delay := cfg.BackoffBase << shift
+	if delay <= 0 || delay > maxBackoffDelay {
+		delay = maxBackoffDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(cfg.BackoffBase)+1))
+}
+
+// doWithRetry builds and sends a request via newReq, retrying transient
+// failures (network errors, 5xx, 429) up to cfg.MaxRetries times with
+// backoffDelay between attempts. Auth failures (401/403) are not
+// retryable and, like any other permanent failure, are returned on the
+// first attempt. ctx cancellation aborts a pending backoff immediately.
+func doWithRetry(ctx context.Context, c httpClient, cfg retryConfig, newReq func() (*http.Request, error)) (*http.Response, error) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		req, ferr := newReq()
+		if ferr != nil {
+			return nil, ferr
+		}
+
+		resp, err = c.Do(req)
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= cfg.MaxRetries {
+			return resp, err
+		}
+
+		delay := backoffDelay(cfg, attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// sequencedHTTPClient returns canned responses/errors in order, repeating
+// the last entry if Do is called more times than there are entries, and
+// records how many times it was called.
+type sequencedHTTPClient struct {
+	responses []*http.Response
+	errs      []error
+	calls     int
+}
+
+func (m *sequencedHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	i := m.calls
+	if i >= len(m.responses) {
+		i = len(m.responses) - 1
+	}
+	m.calls++
+	return m.responses[i], m.errs[i]
+}
+
+func newReqFactory(t *testing.T) func() (*http.Request, error) {
+	t.Helper()
+	return func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, "http://test.example.com", bytes.NewReader(nil))
+	}
+}
+
+// closeTrackingBody records whether Close was called, so tests can assert
+// doWithRetry doesn't leak a response body across retry attempts.
+type closeTrackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestDoWithRetryClosesIntermediateBodies(t *testing.T) {
+	bodies := []*closeTrackingBody{
+		{Reader: bytes.NewReader(nil)},
+		{Reader: bytes.NewReader(nil)},
+	}
+	client := &sequencedHTTPClient{
+		responses: []*http.Response{
+			{StatusCode: 500, Status: "500 Internal Server Error", Header: http.Header{}, Body: bodies[0]},
+			{StatusCode: 200, Status: "200 OK", Header: http.Header{}, Body: bodies[1]},
+		},
+		errs: []error{nil, nil},
+	}
+	cfg := retryConfig{MaxRetries: 1, BackoffBase: time.Millisecond}
+
+	resp, err := doWithRetry(context.Background(), client, cfg, newReqFactory(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bodies[0].closed {
+		t.Error("want the first (retried) response's body to be closed, it wasn't")
+	}
+	if bodies[1].closed {
+		t.Error("want the final response's body left open for the caller to read, it was closed")
+	}
+	if resp.Body != bodies[1] {
+		t.Error("want the returned response to be the final attempt's")
+	}
+}
+
+func TestDoWithRetryAccounting(t *testing.T) {
+	client := &sequencedHTTPClient{
+		responses: []*http.Response{
+			{StatusCode: 500, Status: "500 Internal Server Error", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+			{StatusCode: 500, Status: "500 Internal Server Error", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+			{StatusCode: 200, Status: "200 OK", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+		},
+		errs: []error{nil, nil, nil},
+	}
+	cfg := retryConfig{MaxRetries: 3, BackoffBase: time.Millisecond}
+
+	resp, err := doWithRetry(context.Background(), client, cfg, newReqFactory(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("want status 200, got %d", resp.StatusCode)
+	}
+	if client.calls != 3 {
+		t.Errorf("want 3 calls, got %d", client.calls)
+	}
+}
+
+func TestDoWithRetryExhaustsRetries(t *testing.T) {
+	client := &sequencedHTTPClient{
+		responses: []*http.Response{
+			{StatusCode: 503, Status: "503 Service Unavailable", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+		},
+		errs: []error{nil},
+	}
+	cfg := retryConfig{MaxRetries: 2, BackoffBase: time.Millisecond}
+
+	resp, err := doWithRetry(context.Background(), client, cfg, newReqFactory(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 503 {
+		t.Errorf("want status 503, got %d", resp.StatusCode)
+	}
+	if client.calls != 3 {
+		t.Errorf("want 3 calls (1 initial + 2 retries), got %d", client.calls)
+	}
+}
+
+func TestDoWithRetryAuthFailureNotRetried(t *testing.T) {
+	client := &sequencedHTTPClient{
+		responses: []*http.Response{
+			{StatusCode: 401, Status: "401 Unauthorized", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+		},
+		errs: []error{nil},
+	}
+	cfg := retryConfig{MaxRetries: 3, BackoffBase: time.Millisecond}
+
+	resp, err := doWithRetry(context.Background(), client, cfg, newReqFactory(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 401 {
+		t.Errorf("want status 401, got %d", resp.StatusCode)
+	}
+	if client.calls != 1 {
+		t.Errorf("want 1 call (no retry on auth failure), got %d", client.calls)
+	}
+}
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "0")
+	client := &sequencedHTTPClient{
+		responses: []*http.Response{
+			{StatusCode: 429, Status: "429 Too Many Requests", Header: header, Body: io.NopCloser(bytes.NewReader(nil))},
+			{StatusCode: 200, Status: "200 OK", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+		},
+		errs: []error{nil, nil},
+	}
+	// A large backoff base that would make the test slow if Retry-After
+	// wasn't honored, since it takes priority over the computed backoff.
+	cfg := retryConfig{MaxRetries: 1, BackoffBase: time.Hour}
+
+	start := time.Now()
+	resp, err := doWithRetry(context.Background(), client, cfg, newReqFactory(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("want status 200, got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("want retry honoring Retry-After:0 to be near-instant, took %v", elapsed)
+	}
+}
+
+func TestDoWithRetryContextCancellationMidFlight(t *testing.T) {
+	client := &sequencedHTTPClient{
+		responses: []*http.Response{
+			{StatusCode: 500, Status: "500 Internal Server Error", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))},
+		},
+		errs: []error{nil},
+	}
+	cfg := retryConfig{MaxRetries: 5, BackoffBase: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := doWithRetry(ctx, client, cfg, newReqFactory(t))
+	if err != context.Canceled {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("want 1 call before cancellation stopped retrying, got %d", client.calls)
+	}
+}
+
+func TestBackoffDelayHonorsRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+	resp := &http.Response{Header: header}
+	cfg := retryConfig{BackoffBase: time.Millisecond}
+
+	got := backoffDelay(cfg, 0, resp)
+	if got != 2*time.Second {
+		t.Errorf("want 2s, got %v", got)
+	}
+}
+
+func TestBackoffDelayExponential(t *testing.T) {
+	cfg := retryConfig{BackoffBase: 100 * time.Millisecond}
+
+	for attempt := 0; attempt < 4; attempt++ {
+		got := backoffDelay(cfg, attempt, nil)
+		min := cfg.BackoffBase << attempt
+		max := min * 2
+		if got < min || got > max {
+			t.Errorf("attempt %d: want delay in [%v,%v], got %v", attempt, min, max, got)
+		}
+	}
+}
+
+// TestBackoffDelayClampsLargeAttempt guards against a large RETRY_MAX with a
+// sizable RETRY_BACKOFF_MS shifting the delay into overflow: the result must
+// stay positive and bounded by maxBackoffDelay instead of wrapping negative.
+func TestBackoffDelayClampsLargeAttempt(t *testing.T) {
+	cfg := retryConfig{BackoffBase: time.Hour, MaxRetries: 1000}
+
+	for _, attempt := range []int{30, 62, 1000} {
+		got := backoffDelay(cfg, attempt, nil)
+		if got <= 0 {
+			t.Errorf("attempt %d: want positive delay, got %v", attempt, got)
+		}
+		if got > maxBackoffDelay+cfg.BackoffBase {
+			t.Errorf("attempt %d: want delay bounded near maxBackoffDelay, got %v", attempt, got)
+		}
+	}
+}
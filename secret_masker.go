@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// secretMasker scrubs registered secret values from anything written through
+// writers it wraps, so a secret fetched from DSV never appears verbatim in
+// CI logs once it has been registered.
+type secretMasker struct {
+	mu      sync.Mutex
+	secrets []string
+}
+
+// Register adds val to the set of values that writers returned by Wrap will
+// scrub. Empty values are ignored, since replacing "" would corrupt every
+// subsequent write.
+func (m *secretMasker) Register(val string) {
+	if val == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets = append(m.secrets, val)
+}
+
+// Wrap returns an io.Writer that replaces every occurrence of a registered
+// secret with "***" before forwarding the result to w.
+func (m *secretMasker) Wrap(w io.Writer) io.Writer {
+	return &maskingWriter{masker: m, dst: w}
+}
+
+type maskingWriter struct {
+	masker *secretMasker
+	dst    io.Writer
+}
+
+func (mw *maskingWriter) Write(p []byte) (int, error) {
+	mw.masker.mu.Lock()
+	secrets := append([]string(nil), mw.masker.secrets...)
+	mw.masker.mu.Unlock()
+
+	out := p
+	if len(secrets) > 0 {
+		s := string(p)
+		for _, secret := range secrets {
+			s = strings.ReplaceAll(s, secret, "***")
+		}
+		out = []byte(s)
+	}
+	if _, err := mw.dst.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
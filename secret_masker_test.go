@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSecretMaskerWrap(t *testing.T) {
+	var m secretMasker
+	m.Register("s3cr3t")
+	m.Register("another-secret")
+
+	var buf bytes.Buffer
+	w := m.Wrap(&buf)
+
+	if _, err := w.Write([]byte("token=s3cr3t and also another-secret here\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := buf.String()
+	if strings.Contains(got, "s3cr3t") || strings.Contains(got, "another-secret") {
+		t.Fatalf("secret leaked into output: %q", got)
+	}
+	want := "token=*** and also *** here\n"
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSecretMaskerEmptyValueIgnored(t *testing.T) {
+	var m secretMasker
+	m.Register("")
+
+	var buf bytes.Buffer
+	w := m.Wrap(&buf)
+	if _, err := w.Write([]byte("unchanged")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "unchanged" {
+		t.Errorf("want %q, got %q", "unchanged", buf.String())
+	}
+}
+
+func TestSecretMaskerWrapRegisteredAfterWrap(t *testing.T) {
+	var m secretMasker
+	var buf bytes.Buffer
+	w := m.Wrap(&buf)
+
+	m.Register("late-secret")
+	if _, err := w.Write([]byte("value is late-secret")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "late-secret") {
+		t.Fatalf("secret leaked into output: %q", buf.String())
+	}
+}
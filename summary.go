@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathSummary describes the keys fetched from a single DSV secret path, for
+// reporting to CI without revealing any secret values.
+type pathSummary struct {
+	Path       string
+	OutputKeys []string
+}
+
+// runSummary collects a pathSummary per path fetched during a run, in fetch
+// order, for WriteStepSummary to report once the run succeeds.
+type runSummary struct {
+	Paths []pathSummary
+}
+
+// add records that outputKey was exported from path, creating path's entry
+// on its first key.
+func (s *runSummary) add(path, outputKey string) {
+	for i := range s.Paths {
+		if s.Paths[i].Path == path {
+			s.Paths[i].OutputKeys = append(s.Paths[i].OutputKeys, outputKey)
+			return
+		}
+	}
+	s.Paths = append(s.Paths, pathSummary{Path: path, OutputKeys: []string{outputKey}})
+}
+
+// totalKeys returns the number of keys exported across every path.
+func (s runSummary) totalKeys() int {
+	n := 0
+	for _, p := range s.Paths {
+		n += len(p.OutputKeys)
+	}
+	return n
+}
+
+// markdownTable renders s as a GitHub-flavored markdown table: one row per
+// path, with the number of keys exported and the (redacted) output keys.
+func (s runSummary) markdownTable() string {
+	var b strings.Builder
+	b.WriteString("## DSV secrets fetched\n\n")
+	b.WriteString("| Path | Keys exported | Outputs |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, p := range s.Paths {
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", p.Path, len(p.OutputKeys), strings.Join(p.OutputKeys, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunSummaryAdd(t *testing.T) {
+	var s runSummary
+	s.add("folder1/secret1", "KEY1")
+	s.add("folder1/secret1", "KEY2")
+	s.add("folder2/secret2", "KEY3")
+
+	want := []pathSummary{
+		{Path: "folder1/secret1", OutputKeys: []string{"KEY1", "KEY2"}},
+		{Path: "folder2/secret2", OutputKeys: []string{"KEY3"}},
+	}
+	if len(s.Paths) != len(want) {
+		t.Fatalf("want %d paths, got %d", len(want), len(s.Paths))
+	}
+	for i := range want {
+		if s.Paths[i].Path != want[i].Path || strings.Join(s.Paths[i].OutputKeys, ",") != strings.Join(want[i].OutputKeys, ",") {
+			t.Errorf("path %d: want %+v, got %+v", i, want[i], s.Paths[i])
+		}
+	}
+	if got := s.totalKeys(); got != 3 {
+		t.Errorf("want 3 total keys, got %d", got)
+	}
+}
+
+func TestRunSummaryMarkdownTable(t *testing.T) {
+	var s runSummary
+	s.add("folder1/secret1", "KEY1")
+	s.add("folder1/secret1", "file:out/mykey2")
+
+	table := s.markdownTable()
+	for _, want := range []string{"folder1/secret1", "2", "KEY1, file:out/mykey2"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("markdown table missing %q:\n%s", want, table)
+		}
+	}
+}